@@ -0,0 +1,145 @@
+package job
+
+import (
+	"github.com/pkg/errors"
+	v1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// defaultContainerName is the name of the container generated by
+// NewInlineJob; InlineJobConfig has no field to change it.
+const defaultContainerName = "main"
+
+// VolumeMountOptions describes how a PVC should be mounted into the inline
+// job's container.
+type VolumeMountOptions struct {
+	// MountPath is where the volume is mounted inside the container.
+	MountPath string
+	// ReadOnly mounts the volume read-only.
+	ReadOnly bool
+}
+
+// InlineJobConfig describes a batch/v1 Job to synthesize in code, for
+// callers that don't want to maintain a YAML template.
+type InlineJobConfig struct {
+	// ConfigFile is the kubeconfig used to build the job's client, the same
+	// as NewJob's configFile argument.
+	ConfigFile string
+	// Namespace the job is created in.
+	Namespace string
+	// Name is used as the base for the generated job name.
+	Name string
+	// Image is the container image to run.
+	Image string
+	// Command overrides the image's entrypoint, if set.
+	Command []string
+	// Args are passed to the container.
+	Args []string
+	// ServiceAccount is the pod's service account name.
+	ServiceAccount string
+	// Env is injected into the container.
+	Env []corev1.EnvVar
+	// Volumes maps a PVC name to where it should be mounted.
+	Volumes map[string]VolumeMountOptions
+	// BackoffLimit is the number of retries before the job is marked failed.
+	BackoffLimit *int32
+	// ActiveDeadlineSeconds bounds how long the job may run.
+	ActiveDeadlineSeconds *int64
+	// TTLSecondsAfterFinished garbage-collects the job after it finishes.
+	TTLSecondsAfterFinished *int32
+	// Labels are applied to the job and its pod template.
+	Labels map[string]string
+	// Annotations are applied to the job and its pod template.
+	Annotations map[string]string
+}
+
+// NewInlineJob returns a new Job whose CurrentJob is synthesized from cfg
+// instead of being loaded from a YAML template. All other Job methods
+// (RunJob, WaitJobComplete, Cleanup, removePods) work unchanged against it.
+func NewInlineJob(cfg InlineJobConfig) (*Job, error) {
+	if len(cfg.Image) == 0 {
+		return nil, errors.New("Image is required")
+	}
+	client, err := newClient(cfg.ConfigFile)
+	if err != nil {
+		return nil, err
+	}
+
+	currentJob := buildInlineJob(cfg)
+	currentJob.SetName(generateRandomName(currentJob.Name))
+
+	return &Job{
+		client,
+		currentJob,
+		cfg.Args,
+		defaultContainerName,
+		0,
+		nil,
+		JobOverlay{},
+	}, nil
+}
+
+// buildInlineJob synthesizes a batch/v1 Job from cfg.
+func buildInlineJob(cfg InlineJobConfig) *v1.Job {
+	volumes, volumeMounts := inlineVolumes(cfg.Volumes)
+
+	container := corev1.Container{
+		Name:         defaultContainerName,
+		Image:        cfg.Image,
+		Command:      cfg.Command,
+		Args:         cfg.Args,
+		Env:          cfg.Env,
+		VolumeMounts: volumeMounts,
+	}
+
+	return &v1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        cfg.Name,
+			Namespace:   cfg.Namespace,
+			Labels:      cfg.Labels,
+			Annotations: cfg.Annotations,
+		},
+		Spec: v1.JobSpec{
+			BackoffLimit:            cfg.BackoffLimit,
+			ActiveDeadlineSeconds:   cfg.ActiveDeadlineSeconds,
+			TTLSecondsAfterFinished: cfg.TTLSecondsAfterFinished,
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels:      cfg.Labels,
+					Annotations: cfg.Annotations,
+				},
+				Spec: corev1.PodSpec{
+					ServiceAccountName: cfg.ServiceAccount,
+					RestartPolicy:      corev1.RestartPolicyNever,
+					Containers:         []corev1.Container{container},
+					Volumes:            volumes,
+				},
+			},
+		},
+	}
+}
+
+// inlineVolumes turns a PVC-name-to-mount-options map into the volume and
+// volume mount slices a PodSpec needs.
+func inlineVolumes(volumes map[string]VolumeMountOptions) ([]corev1.Volume, []corev1.VolumeMount) {
+	podVolumes := make([]corev1.Volume, 0, len(volumes))
+	mounts := make([]corev1.VolumeMount, 0, len(volumes))
+	for pvcName, opts := range volumes {
+		podVolumes = append(podVolumes, corev1.Volume{
+			Name: pvcName,
+			VolumeSource: corev1.VolumeSource{
+				PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
+					ClaimName: pvcName,
+					ReadOnly:  opts.ReadOnly,
+				},
+			},
+		})
+		mounts = append(mounts, corev1.VolumeMount{
+			Name:      pvcName,
+			MountPath: opts.MountPath,
+			ReadOnly:  opts.ReadOnly,
+		})
+	}
+	return podVolumes, mounts
+}
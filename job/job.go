@@ -2,15 +2,10 @@ package job
 
 import (
 	"context"
-	"crypto/md5"
 	"crypto/rand"
-	"encoding/hex"
 	"fmt"
 	"io"
-	"io/ioutil"
 	"net/http"
-	"os"
-	"strings"
 	"time"
 
 	shellwords "github.com/mattn/go-shellwords"
@@ -19,7 +14,11 @@ import (
 
 	"github.com/ghodss/yaml"
 	v1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/strategicpatch"
+	"k8s.io/apimachinery/pkg/watch"
 	"k8s.io/client-go/kubernetes"
 )
 
@@ -35,16 +34,38 @@ type Job struct {
 	Container string
 	// If you set 0, timeout is ignored.
 	Timeout time.Duration
+	// OnStatus, if set, is called with the Active/Succeeded/Failed counts
+	// of the job every time its status is updated while waiting.
+	OnStatus func(JobStatus)
+	// Overlay is applied to the target container and pod template just
+	// before Create, on top of whatever the template already defines.
+	Overlay JobOverlay
+}
+
+// JobStatus is a snapshot of the pod counts reported by a running job.
+type JobStatus struct {
+	Active    int32
+	Succeeded int32
+	Failed    int32
 }
 
 // NewJob returns a new Job struct, and initialize kubernetes client.
-// It read the job definition yaml file, and unmarshal to batch/v1/Job.
-func NewJob(configFile, currentFile, command, container string, timeout time.Duration) (*Job, error) {
+//
+// Deprecated: use NewJobContext instead. NewJob will be removed in a future release.
+func NewJob(configFile string, sources []TemplateSource, command, container string, timeout time.Duration) (*Job, error) {
+	return NewJobContext(context.Background(), configFile, sources, command, container, timeout)
+}
+
+// NewJobContext returns a new Job struct, and initialize kubernetes client.
+// It fetches the job definition from sources, merging them in order
+// (strategic-merge, like Helm does internally) before unmarshalling the
+// result to batch/v1/Job.
+func NewJobContext(ctx context.Context, configFile string, sources []TemplateSource, command, container string, timeout time.Duration) (*Job, error) {
 	if len(configFile) == 0 {
 		return nil, errors.New("Config file is required")
 	}
-	if len(currentFile) == 0 {
-		return nil, errors.New("Template file is required")
+	if len(sources) == 0 {
+		return nil, errors.New("At least one template source is required")
 	}
 	if len(container) == 0 {
 		return nil, errors.New("Container is required")
@@ -53,16 +74,7 @@ func NewJob(configFile, currentFile, command, container string, timeout time.Dur
 	if err != nil {
 		return nil, err
 	}
-	downloaded, err := downloadFile(currentFile)
-	if err != nil {
-		return nil, err
-	}
-	bytes, err := ioutil.ReadFile(downloaded)
-	if err != nil {
-		return nil, err
-	}
-	var currentJob v1.Job
-	err = yaml.Unmarshal(bytes, &currentJob)
+	currentJob, err := loadTemplate(ctx, sources)
 	if err != nil {
 		return nil, err
 	}
@@ -80,51 +92,59 @@ func NewJob(configFile, currentFile, command, container string, timeout time.Dur
 
 	return &Job{
 		client,
-		&currentJob,
+		currentJob,
 		args,
 		container,
 		timeout,
+		nil,
+		JobOverlay{},
 	}, nil
 }
 
-func downloadFile(rawurl string) (string, error) {
-	if !strings.HasPrefix(rawurl, "https://") {
-		return rawurl, nil
-	}
-
-	req, err := http.NewRequest("GET", rawurl, nil)
+// loadTemplate fetches every source, merges them in order, and unmarshals
+// the result into a batch/v1/Job.
+func loadTemplate(ctx context.Context, sources []TemplateSource) (*v1.Job, error) {
+	merged, err := mergeTemplates(ctx, sources)
 	if err != nil {
-		return rawurl, err
-	}
-	token := os.Getenv("GITHUB_TOKEN")
-	if len(token) > 0 {
-		req.Header.Set("Authorization", "token "+token)
-		req.Header.Set("Accept", "application/vnd.github.v3.raw")
-	}
-	client := new(http.Client)
-	resp, err := client.Do(req)
-	if err != nil {
-		return rawurl, err
+		return nil, err
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return rawurl, fmt.Errorf("Could not read template file from %s", rawurl)
+	var currentJob v1.Job
+	if err := yaml.Unmarshal(merged, &currentJob); err != nil {
+		return nil, err
 	}
+	return &currentJob, nil
+}
 
-	// Get random string from url.
-	hasher := md5.New()
-	hasher.Write([]byte(rawurl))
-	downloaded := "/tmp/" + hex.EncodeToString(hasher.Sum(nil)) + ".yml"
-	out, err := os.Create(downloaded)
-	if err != nil {
-		return rawurl, err
-	}
-	defer out.Close()
+// mergeTemplates fetches each source and strategic-merge-patches it onto the
+// previous ones, so later sources override or extend earlier ones.
+func mergeTemplates(ctx context.Context, sources []TemplateSource) ([]byte, error) {
+	var merged []byte
+	for i, source := range sources {
+		rc, err := source.Fetch(ctx)
+		if err != nil {
+			return nil, err
+		}
+		data, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			return nil, err
+		}
 
-	// Write the body to file
-	_, err = io.Copy(out, resp.Body)
-	return downloaded, err
+		patch, err := yaml.YAMLToJSON(data)
+		if err != nil {
+			return nil, err
+		}
+		if i == 0 {
+			merged = patch
+			continue
+		}
+		merged, err = strategicpatch.StrategicMergePatch(merged, patch, v1.Job{})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return merged, nil
 }
 
 func generateRandomName(name string) string {
@@ -140,16 +160,24 @@ func secureRandomStr(b int) string {
 	return fmt.Sprintf("%x", k)
 }
 
-// RunJob is run a kubernetes job, and returns the job information.
+// RunJob runs a kubernetes job, and returns the job information.
+//
+// Deprecated: use RunJobContext instead. RunJob will be removed in a future release.
 func (j *Job) RunJob() (*v1.Job, error) {
+	return j.RunJobContext(context.Background())
+}
+
+// RunJobContext runs a kubernetes job, and returns the job information.
+func (j *Job) RunJobContext(ctx context.Context) (*v1.Job, error) {
 	currentJob := j.CurrentJob.DeepCopy()
 	index, err := findContainerIndex(currentJob, j.Container)
 	if err != nil {
 		return nil, err
 	}
 	currentJob.Spec.Template.Spec.Containers[index].Args = j.Args
+	applyOverlay(currentJob, index, j.Overlay)
 
-	resultJob, err := j.client.BatchV1().Jobs(j.CurrentJob.Namespace).Create(currentJob)
+	resultJob, err := j.client.BatchV1().Jobs(j.CurrentJob.Namespace).Create(ctx, currentJob, metav1.CreateOptions{})
 	if err != nil {
 		return nil, err
 	}
@@ -170,81 +198,184 @@ func findContainerIndex(job *v1.Job, containerName string) (int, error) {
 func (j *Job) WaitJob(ctx context.Context, job *v1.Job) error {
 	log.Info("Waiting for running job...")
 
-	errCh := make(chan error, 1)
-	done := make(chan struct{}, 1)
-	go func() {
-		err := j.WaitJobComplete(job)
-		if err != nil {
-			errCh <- err
-		}
-		close(done)
-	}()
-	select {
-	case err := <-errCh:
-		if err != nil {
-			return err
-		}
-	case <-done:
-		log.Info("Job is succeeded")
-	case <-ctx.Done():
-		return errors.New("process timeout")
+	err := j.WaitJobComplete(ctx, job)
+	if err != nil {
+		return err
 	}
 
+	log.Info("Job is succeeded")
 	return nil
 }
 
-// WaitJobComplete waits the completion of the job.
-// If the job is failed, this function returns error.
-// If the job is succeeded, this function returns nil.
-func (j *Job) WaitJobComplete(job *v1.Job) error {
-retry:
+// maxWatchReconnects bounds how many times WaitJobComplete will silently
+// re-establish an expired watch without having seen any event in between.
+// This caps the reconnect loop for a permanent failure (bad field selector,
+// RBAC denial, deleted namespace) instead of hammering the API server
+// forever.
+const maxWatchReconnects = 10
+
+// watchReconnectBackoff is the delay before the first reconnect attempt; it
+// doubles on each consecutive reconnect up to watchReconnectBackoffCap.
+const watchReconnectBackoff = 1 * time.Second
+const watchReconnectBackoffCap = 30 * time.Second
+
+// WaitJobComplete watches the job until it reaches a terminal condition.
+// It returns nil once a JobComplete condition appears, and an error once a
+// JobFailed condition appears or ctx is done.
+func (j *Job) WaitJobComplete(ctx context.Context, job *v1.Job) error {
+	resourceVersion := job.ResourceVersion
+	backoff := watchReconnectBackoff
+	reconnects := 0
+
 	for {
-		time.Sleep(3 * time.Second)
-		running, err := j.client.BatchV1().Jobs(job.Namespace).Get(job.Name, metav1.GetOptions{})
+		watcher, err := j.watchJob(ctx, job.Namespace, job.Name, resourceVersion)
 		if err != nil {
 			return err
 		}
-		if running.Status.Active == 0 {
-			return checkJobConditions(running.Status.Conditions)
+
+		var seenResourceVersion string
+		seenResourceVersion, err = j.consumeJobEvents(ctx, watcher)
+		watcher.Stop()
+		if err != errWatchExpired {
+			return err
+		}
+
+		// Progress resets the reconnect budget; a run of expirations with no
+		// events in between is treated as a permanent failure.
+		if seenResourceVersion != "" {
+			resourceVersion = seenResourceVersion
+			reconnects = 0
+			backoff = watchReconnectBackoff
+			continue
 		}
-		continue retry
 
+		reconnects++
+		if reconnects > maxWatchReconnects {
+			return fmt.Errorf("job watch failed to reconnect after %d attempts", maxWatchReconnects)
+		}
+		log.Infof("Job watch expired, reconnecting in %s (attempt %d/%d)...", backoff, reconnects, maxWatchReconnects)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+		if backoff > watchReconnectBackoffCap {
+			backoff = watchReconnectBackoffCap
+		}
 	}
-	return nil
+}
+
+// errWatchExpired signals that the watch's resourceVersion is too old and
+// must be re-established from the latest observed version.
+var errWatchExpired = errors.New("job watch expired")
+
+// watchJob opens a watch scoped to a single job, starting from resourceVersion.
+func (j *Job) watchJob(ctx context.Context, namespace, name, resourceVersion string) (watch.Interface, error) {
+	return j.client.BatchV1().Jobs(namespace).Watch(ctx, metav1.ListOptions{
+		FieldSelector:   "metadata.name=" + name,
+		ResourceVersion: resourceVersion,
+	})
+}
+
+// consumeJobEvents reads events off watcher until the job reaches a terminal
+// condition, ctx is done, or the watch expires. It returns the last observed
+// resourceVersion so the caller can resume a fresh watch from there.
+func (j *Job) consumeJobEvents(ctx context.Context, watcher watch.Interface) (string, error) {
+	resourceVersion := ""
+	for {
+		select {
+		case <-ctx.Done():
+			return resourceVersion, ctx.Err()
+		case event, ok := <-watcher.ResultChan():
+			if !ok {
+				return resourceVersion, errWatchExpired
+			}
+			if event.Type == watch.Error {
+				return resourceVersion, classifyWatchError(event.Object)
+			}
+			running, ok := event.Object.(*v1.Job)
+			if !ok {
+				continue
+			}
+			resourceVersion = running.ResourceVersion
+			if event.Type != watch.Added && event.Type != watch.Modified {
+				continue
+			}
+			if j.OnStatus != nil {
+				j.OnStatus(JobStatus{
+					Active:    running.Status.Active,
+					Succeeded: running.Status.Succeeded,
+					Failed:    running.Status.Failed,
+				})
+			}
+			if done, err := checkJobConditions(running.Status.Conditions); done {
+				return resourceVersion, err
+			}
+		}
+	}
+}
 
+// classifyWatchError turns a watch.Error event's payload into errWatchExpired
+// when it represents an expired/410-Gone watch that should simply be
+// re-established, or into the underlying error otherwise so a permanent
+// failure (RBAC denial, bad field selector, deleted namespace) surfaces to
+// the caller instead of being retried forever.
+func classifyWatchError(obj runtime.Object) error {
+	status, ok := obj.(*metav1.Status)
+	if !ok {
+		return fmt.Errorf("job watch failed: %v", obj)
+	}
+	if status.Code == http.StatusGone || status.Reason == metav1.StatusReasonExpired {
+		return errWatchExpired
+	}
+	return fmt.Errorf("job watch failed: %s", status.Message)
 }
 
-// checkJobConditions checks conditions of all jobs.
-// If any job is failed, returns error.
-func checkJobConditions(conditions []v1.JobCondition) error {
+// checkJobConditions inspects the conditions of a job.
+// It returns done=true once either a JobComplete or JobFailed condition is
+// present, along with a non-nil error in the JobFailed case.
+func checkJobConditions(conditions []v1.JobCondition) (done bool, err error) {
 	for _, condition := range conditions {
-		if condition.Type == v1.JobFailed {
-			return fmt.Errorf("Job is failed: %s", condition.Reason)
+		if condition.Status != corev1.ConditionTrue {
+			continue
+		}
+		switch condition.Type {
+		case v1.JobComplete:
+			return true, nil
+		case v1.JobFailed:
+			return true, fmt.Errorf("Job is failed: %s", condition.Reason)
 		}
 	}
-	return nil
+	return false, nil
 }
 
 // Cleanup removes the job from the kubernetes cluster.
+//
+// Deprecated: use CleanupContext instead. Cleanup will be removed in a future release.
 func (j *Job) Cleanup() error {
-	err := j.removePods()
+	return j.CleanupContext(context.Background())
+}
+
+// CleanupContext removes the job from the kubernetes cluster.
+func (j *Job) CleanupContext(ctx context.Context) error {
+	err := j.removePods(ctx)
 	if err != nil {
 		return err
 	}
 	log.Infof("Removing the job: %s", j.CurrentJob.Name)
-	options := metav1.DeleteOptions{}
-	return j.client.BatchV1().Jobs(j.CurrentJob.Namespace).Delete(j.CurrentJob.Name, &options)
+	return j.client.BatchV1().Jobs(j.CurrentJob.Namespace).Delete(ctx, j.CurrentJob.Name, metav1.DeleteOptions{})
 }
 
-func (j *Job) removePods() error {
+func (j *Job) removePods(ctx context.Context) error {
 	// Use job-name to find pods which are related the job.
 	labels := "job-name=" + j.CurrentJob.Name
 	log.Infof("Remove related pods which labels is: %s", labels)
 	listOptions := metav1.ListOptions{
 		LabelSelector: labels,
 	}
-	options := &metav1.DeleteOptions{
+	options := metav1.DeleteOptions{
 		GracePeriodSeconds: nil, // Use default grace period seconds.
 	}
-	return j.client.CoreV1().Pods(j.CurrentJob.Namespace).DeleteCollection(options, listOptions)
+	return j.client.CoreV1().Pods(j.CurrentJob.Namespace).DeleteCollection(ctx, options, listOptions)
 }
@@ -0,0 +1,241 @@
+package job
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	v1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	clienttesting "k8s.io/client-go/testing"
+
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+const testNamespace = "default"
+
+func newTestJob(name string) *v1.Job {
+	return &v1.Job{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: testNamespace},
+		Spec: v1.JobSpec{
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{{Name: "main", Image: "busybox"}},
+				},
+			},
+		},
+	}
+}
+
+// setJobCondition updates job's status condition through the fake clientset,
+// which delivers it as a Modified watch event to anyone watching it.
+func setJobCondition(t *testing.T, client *fake.Clientset, job *v1.Job, conditionType v1.JobConditionType) {
+	t.Helper()
+	updated := job.DeepCopy()
+	updated.Status.Conditions = []v1.JobCondition{{Type: conditionType, Status: corev1.ConditionTrue}}
+	if _, err := client.BatchV1().Jobs(testNamespace).Update(context.Background(), updated, metav1.UpdateOptions{}); err != nil {
+		t.Fatalf("updating job status: %v", err)
+	}
+}
+
+func TestWaitJobCompleteSucceedsOnJobComplete(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	created, err := client.BatchV1().Jobs(testNamespace).Create(context.Background(), newTestJob("succeeds"), metav1.CreateOptions{})
+	if err != nil {
+		t.Fatalf("creating job: %v", err)
+	}
+
+	j := &Job{client: client}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	errCh := make(chan error, 1)
+	go func() { errCh <- j.WaitJobComplete(ctx, created) }()
+
+	waitForWatchEstablished(t)
+	setJobCondition(t, client, created, v1.JobComplete)
+
+	select {
+	case err := <-errCh:
+		if err != nil {
+			t.Fatalf("WaitJobComplete() = %v, want nil", err)
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatal("timed out waiting for WaitJobComplete to return")
+	}
+}
+
+func TestWaitJobCompleteFailsOnJobFailed(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	created, err := client.BatchV1().Jobs(testNamespace).Create(context.Background(), newTestJob("fails"), metav1.CreateOptions{})
+	if err != nil {
+		t.Fatalf("creating job: %v", err)
+	}
+
+	j := &Job{client: client}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	errCh := make(chan error, 1)
+	go func() { errCh <- j.WaitJobComplete(ctx, created) }()
+
+	waitForWatchEstablished(t)
+	setJobCondition(t, client, created, v1.JobFailed)
+
+	select {
+	case err := <-errCh:
+		if err == nil {
+			t.Fatal("WaitJobComplete() = nil, want an error")
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatal("timed out waiting for WaitJobComplete to return")
+	}
+}
+
+func TestWaitJobCompleteReturnsDeadlineExceededWithNoEvents(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	created, err := client.BatchV1().Jobs(testNamespace).Create(context.Background(), newTestJob("idle"), metav1.CreateOptions{})
+	if err != nil {
+		t.Fatalf("creating job: %v", err)
+	}
+
+	j := &Job{client: client}
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	err = j.WaitJobComplete(ctx, created)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("WaitJobComplete() = %v, want context.DeadlineExceeded", err)
+	}
+}
+
+func TestRunJobContextAppliesArgsAndOverlay(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	j := &Job{
+		client:     client,
+		CurrentJob: newTestJob("base"),
+		Args:       []string{"migrate"},
+		Container:  "main",
+		Overlay:    JobOverlay{ImageTag: "v2"},
+	}
+
+	resultJob, err := j.RunJobContext(context.Background())
+	if err != nil {
+		t.Fatalf("RunJobContext() error = %v", err)
+	}
+
+	container := resultJob.Spec.Template.Spec.Containers[0]
+	if got := container.Args; len(got) != 1 || got[0] != "migrate" {
+		t.Errorf("Args = %+v, want [migrate]", got)
+	}
+	if got := container.Image; got != "busybox:v2" {
+		t.Errorf("Image = %q, want %q", got, "busybox:v2")
+	}
+}
+
+// TestRunFailsFastOnCreateError covers the "timeout/error mid-create" path:
+// Run must surface the Create error without ever starting a watch or a
+// cleanup.
+func TestRunFailsFastOnCreateError(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	createErr := errors.New("create failed")
+	client.PrependReactor("create", "jobs", func(clienttesting.Action) (bool, runtime.Object, error) {
+		return true, nil, createErr
+	})
+
+	watched := false
+	client.PrependWatchReactor("jobs", func(clienttesting.Action) (bool, watch.Interface, error) {
+		watched = true
+		return false, nil, nil
+	})
+	deleted := false
+	client.PrependReactor("delete", "jobs", func(clienttesting.Action) (bool, runtime.Object, error) {
+		deleted = true
+		return false, nil, nil
+	})
+
+	j := &Job{client: client, CurrentJob: newTestJob("base"), Container: "main"}
+	var buf bytes.Buffer
+	err := j.Run(context.Background(), &buf)
+
+	if !errors.Is(err, createErr) {
+		t.Fatalf("Run() error = %v, want %v", err, createErr)
+	}
+	if watched {
+		t.Error("Run() should not start a watch when Create fails")
+	}
+	if deleted {
+		t.Error("Run() should not attempt Cleanup when Create fails")
+	}
+}
+
+// TestRunCleansUpOnDeadlineExceeded covers the "timeout mid-watch" path: once
+// j.Timeout fires while waiting, Run must still clean up the created job
+// with a fresh context instead of leaving it orphaned.
+func TestRunCleansUpOnDeadlineExceeded(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	j := &Job{
+		client:     client,
+		CurrentJob: newTestJob("base"),
+		Container:  "main",
+		Timeout:    10 * time.Millisecond,
+	}
+
+	var buf bytes.Buffer
+	err := j.Run(context.Background(), &buf)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Run() error = %v, want context.DeadlineExceeded", err)
+	}
+
+	_, getErr := client.BatchV1().Jobs(testNamespace).Get(context.Background(), "base", metav1.GetOptions{})
+	if !k8serrors.IsNotFound(getErr) {
+		t.Fatalf("expected job to be cleaned up, Get() error = %v", getErr)
+	}
+}
+
+// TestRunDoesNotCleanUpOnSuccess covers the "clean completion" path: Cleanup
+// must not run when the job completes before any deadline.
+func TestRunDoesNotCleanUpOnSuccess(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	j := &Job{client: client, CurrentJob: newTestJob("base"), Container: "main"}
+
+	done := make(chan error, 1)
+	go func() {
+		var buf bytes.Buffer
+		done <- j.Run(context.Background(), &buf)
+	}()
+
+	waitForWatchEstablished(t)
+	created, err := client.BatchV1().Jobs(testNamespace).Get(context.Background(), "base", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("getting created job: %v", err)
+	}
+	setJobCondition(t, client, created, v1.JobComplete)
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("Run() error = %v, want nil", err)
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatal("timed out waiting for Run to return")
+	}
+
+	if _, err := client.BatchV1().Jobs(testNamespace).Get(context.Background(), "base", metav1.GetOptions{}); err != nil {
+		t.Fatalf("job should not have been cleaned up on success, Get() error = %v", err)
+	}
+}
+
+// waitForWatchEstablished gives the fake clientset's watch goroutine time to
+// register before the test mutates the watched object. The fake client has
+// no signal for "watch is ready", so a short sleep is the pragmatic option.
+func waitForWatchEstablished(t *testing.T) {
+	t.Helper()
+	time.Sleep(50 * time.Millisecond)
+}
@@ -0,0 +1,218 @@
+package job
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/watch"
+)
+
+// podPollInterval is how often StreamLogs checks for new pods and for a
+// container to leave the Waiting state.
+const podPollInterval = 2 * time.Second
+
+// StreamLogs tails the logs of the job's target container for every pod the
+// job creates, and copies them to out with a "[pod-name] " line prefix. It
+// watches for pods rather than listing once, fanning out a new goroutine for
+// each newly-seen pod, so jobs with parallelism > 1 or backoff-retry
+// replacement pods are all covered. It returns once ctx is done.
+func (j *Job) StreamLogs(ctx context.Context, out io.Writer) error {
+	listOptions := metav1.ListOptions{LabelSelector: "job-name=" + j.CurrentJob.Name}
+
+	watcher, err := j.client.CoreV1().Pods(j.CurrentJob.Namespace).Watch(ctx, listOptions)
+	if err != nil {
+		return err
+	}
+	defer watcher.Stop()
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	seen := make(map[string]bool)
+	var errsMu sync.Mutex
+	var errs []error
+
+	for {
+		select {
+		case <-ctx.Done():
+			wg.Wait()
+			return firstErr(errs)
+		case event, ok := <-watcher.ResultChan():
+			if !ok {
+				wg.Wait()
+				return firstErr(errs)
+			}
+			if event.Type != watch.Added && event.Type != watch.Modified {
+				continue
+			}
+			pod, ok := event.Object.(*corev1.Pod)
+			if !ok || seen[pod.Name] {
+				continue
+			}
+			seen[pod.Name] = true
+
+			wg.Add(1)
+			go func(podName string) {
+				defer wg.Done()
+				writer := &prefixWriter{mu: &mu, out: out, prefix: podName}
+				if err := j.streamPodLogs(ctx, podName, writer); err != nil {
+					errsMu.Lock()
+					errs = append(errs, err)
+					errsMu.Unlock()
+				}
+			}(pod.Name)
+		}
+	}
+}
+
+// firstErr returns the first non-nil error in errs, or nil if there is none.
+func firstErr(errs []error) error {
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// streamPodLogs waits for the target container to start, then follows its
+// logs until the log stream ends or ctx is done.
+func (j *Job) streamPodLogs(ctx context.Context, podName string, out io.Writer) error {
+	if err := j.waitForContainerStarted(ctx, podName); err != nil {
+		return err
+	}
+
+	var stream io.ReadCloser
+	var err error
+	for {
+		req := j.client.CoreV1().Pods(j.CurrentJob.Namespace).GetLogs(podName, &corev1.PodLogOptions{
+			Container: j.Container,
+			Follow:    true,
+		})
+		stream, err = req.Stream(ctx)
+		if err == nil {
+			break
+		}
+		// The container status can report started before the kubelet has
+		// actually exposed logs for it, so retry until it succeeds or ctx
+		// is done.
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(podPollInterval):
+		}
+	}
+	defer stream.Close()
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := io.Copy(out, stream)
+		done <- err
+	}()
+
+	select {
+	case <-ctx.Done():
+		return nil
+	case err := <-done:
+		return err
+	}
+}
+
+// waitForContainerStarted blocks until the job's target container on podName
+// leaves the Waiting state (e.g. ContainerCreating, PodInitializing).
+func (j *Job) waitForContainerStarted(ctx context.Context, podName string) error {
+	for {
+		pod, err := j.client.CoreV1().Pods(j.CurrentJob.Namespace).Get(ctx, podName, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		for _, status := range pod.Status.ContainerStatuses {
+			if status.Name == j.Container && status.State.Waiting == nil {
+				return nil
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(podPollInterval):
+		}
+	}
+}
+
+// prefixWriter prefixes every line written to it with a pod name and
+// serializes writes from concurrent pod streams behind mu.
+type prefixWriter struct {
+	mu     *sync.Mutex
+	out    io.Writer
+	prefix string
+}
+
+func (w *prefixWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	for _, line := range strings.Split(strings.TrimRight(string(p), "\n"), "\n") {
+		if _, err := fmt.Fprintf(w.out, "[%s] %s\n", w.prefix, line); err != nil {
+			return 0, err
+		}
+	}
+	return len(p), nil
+}
+
+// cleanupTimeout bounds the fresh context given to Cleanup when j.Timeout
+// fires mid-run, so an already-timed-out caller still gets a chance to
+// remove the orphaned job.
+const cleanupTimeout = 30 * time.Second
+
+// Run creates the job, streams its target container's logs, and waits for
+// completion. It is the convenience entry point most callers want instead of
+// calling RunJobContext, StreamLogs, and WaitJob separately.
+//
+// If j.Timeout is non-zero, it is applied as a deadline on ctx for the whole
+// run; if that deadline fires, Run still attempts Cleanup with a short fresh
+// context so the job isn't left running in the cluster.
+func (j *Job) Run(ctx context.Context, out io.Writer) error {
+	if j.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, j.Timeout)
+		defer cancel()
+	}
+
+	resultJob, err := j.RunJobContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	logsCtx, cancelLogs := context.WithCancel(ctx)
+	defer cancelLogs()
+
+	logsErrCh := make(chan error, 1)
+	go func() {
+		logsErrCh <- j.StreamLogs(logsCtx, out)
+	}()
+
+	waitErr := j.WaitJob(ctx, resultJob)
+	cancelLogs()
+	if logsErr := <-logsErrCh; logsErr != nil && !errors.Is(logsErr, context.Canceled) && !errors.Is(logsErr, context.DeadlineExceeded) {
+		log.Warnf("Error while streaming job logs: %v", logsErr)
+	}
+
+	if errors.Is(waitErr, context.DeadlineExceeded) {
+		cleanupCtx, cancelCleanup := context.WithTimeout(context.Background(), cleanupTimeout)
+		defer cancelCleanup()
+		if cleanupErr := j.CleanupContext(cleanupCtx); cleanupErr != nil {
+			log.Warnf("Error cleaning up timed-out job: %v", cleanupErr)
+		}
+	}
+
+	return waitErr
+}
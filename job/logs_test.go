@@ -0,0 +1,82 @@
+package job
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func newRunningTestPod(name, jobName string) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: testNamespace,
+			Labels:    map[string]string{"job-name": jobName},
+		},
+		Status: corev1.PodStatus{
+			ContainerStatuses: []corev1.ContainerStatus{
+				{
+					Name:  "main",
+					State: corev1.ContainerState{Running: &corev1.ContainerStateRunning{}},
+				},
+			},
+		},
+	}
+}
+
+// TestStreamLogsCoversPodsCreatedAfterTheFirst covers the backoff-retry
+// scenario: a replacement pod created after StreamLogs has already started
+// must still have its logs streamed, not just the first pod seen.
+func TestStreamLogsCoversPodsCreatedAfterTheFirst(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	j := &Job{
+		client:     client,
+		CurrentJob: newTestJob("retries"),
+		Container:  "main",
+	}
+
+	var buf bytes.Buffer
+	ctx, cancel := context.WithCancel(context.Background())
+
+	done := make(chan error, 1)
+	go func() { done <- j.StreamLogs(ctx, &buf) }()
+
+	waitForWatchEstablished(t)
+	if _, err := client.CoreV1().Pods(testNamespace).Create(ctx, newRunningTestPod("retries-1", "retries"), metav1.CreateOptions{}); err != nil {
+		t.Fatalf("creating first pod: %v", err)
+	}
+
+	// Simulate a BackoffLimit retry replacing the first pod some time later,
+	// well after StreamLogs' initial watch has already been established.
+	time.Sleep(300 * time.Millisecond)
+	if _, err := client.CoreV1().Pods(testNamespace).Create(ctx, newRunningTestPod("retries-2", "retries"), metav1.CreateOptions{}); err != nil {
+		t.Fatalf("creating retry pod: %v", err)
+	}
+
+	time.Sleep(200 * time.Millisecond)
+	cancel()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("StreamLogs() error = %v, want nil", err)
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatal("timed out waiting for StreamLogs to return")
+	}
+
+	output := buf.String()
+	if !strings.Contains(output, "[retries-1] fake logs") {
+		t.Errorf("output missing logs from first pod: %q", output)
+	}
+	if !strings.Contains(output, "[retries-2] fake logs") {
+		t.Errorf("output missing logs from retry pod: %q", output)
+	}
+}
@@ -0,0 +1,202 @@
+package job
+
+import (
+	"reflect"
+	"strings"
+
+	v1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// JobOverlay patches the target container and pod template of a job just
+// before it is created, so callers don't have to pre-render their own YAML
+// to swap an image tag, tweak resources, or inject env vars.
+type JobOverlay struct {
+	// Image replaces the target container's image outright.
+	Image string
+	// ImageTag replaces only the tag of the target container's image,
+	// mirroring the image-replace logic common to CI-driven deploy tools.
+	// Ignored if Image is set.
+	ImageTag string
+	// Env is merged into the target container's env by name, overlay wins.
+	Env []corev1.EnvVar
+	// EnvFrom is appended to the target container's envFrom.
+	EnvFrom []corev1.EnvFromSource
+	// Resources replaces the target container's resource requirements.
+	Resources corev1.ResourceRequirements
+	// NodeSelector replaces the pod template's node selector.
+	NodeSelector map[string]string
+	// Tolerations replaces the pod template's tolerations.
+	Tolerations []corev1.Toleration
+	// Labels is merged into both the job and the pod template, overlay wins.
+	Labels map[string]string
+	// Annotations is merged into both the job and the pod template, overlay wins.
+	Annotations map[string]string
+	// BackoffLimit replaces the job's backoff limit.
+	BackoffLimit *int32
+	// ActiveDeadlineSeconds replaces the job's active deadline.
+	ActiveDeadlineSeconds *int64
+	// ServiceAccountName replaces the pod template's service account.
+	ServiceAccountName string
+}
+
+// WithImage sets the overlay's target container image.
+func (j *Job) WithImage(image string) *Job {
+	j.Overlay.Image = image
+	return j
+}
+
+// WithImageTag sets the overlay's target container image tag.
+func (j *Job) WithImageTag(tag string) *Job {
+	j.Overlay.ImageTag = tag
+	return j
+}
+
+// WithEnv merges env vars into the overlay's env by name.
+func (j *Job) WithEnv(env ...corev1.EnvVar) *Job {
+	j.Overlay.Env = mergeEnvVars(j.Overlay.Env, env)
+	return j
+}
+
+// WithEnvFrom appends envFrom sources to the overlay.
+func (j *Job) WithEnvFrom(envFrom ...corev1.EnvFromSource) *Job {
+	j.Overlay.EnvFrom = append(j.Overlay.EnvFrom, envFrom...)
+	return j
+}
+
+// WithResources sets the overlay's target container resource requirements.
+func (j *Job) WithResources(resources corev1.ResourceRequirements) *Job {
+	j.Overlay.Resources = resources
+	return j
+}
+
+// WithNodeSelector sets the overlay's pod template node selector.
+func (j *Job) WithNodeSelector(nodeSelector map[string]string) *Job {
+	j.Overlay.NodeSelector = nodeSelector
+	return j
+}
+
+// WithTolerations sets the overlay's pod template tolerations.
+func (j *Job) WithTolerations(tolerations ...corev1.Toleration) *Job {
+	j.Overlay.Tolerations = tolerations
+	return j
+}
+
+// WithLabels merges labels into the overlay's job and pod template labels.
+func (j *Job) WithLabels(labels map[string]string) *Job {
+	j.Overlay.Labels = mergeStringMaps(j.Overlay.Labels, labels)
+	return j
+}
+
+// WithAnnotations merges annotations into the overlay's job and pod template annotations.
+func (j *Job) WithAnnotations(annotations map[string]string) *Job {
+	j.Overlay.Annotations = mergeStringMaps(j.Overlay.Annotations, annotations)
+	return j
+}
+
+// WithBackoffLimit sets the overlay's job backoff limit.
+func (j *Job) WithBackoffLimit(backoffLimit int32) *Job {
+	j.Overlay.BackoffLimit = &backoffLimit
+	return j
+}
+
+// WithActiveDeadlineSeconds sets the overlay's job active deadline.
+func (j *Job) WithActiveDeadlineSeconds(activeDeadlineSeconds int64) *Job {
+	j.Overlay.ActiveDeadlineSeconds = &activeDeadlineSeconds
+	return j
+}
+
+// WithServiceAccountName sets the overlay's pod template service account.
+func (j *Job) WithServiceAccountName(serviceAccountName string) *Job {
+	j.Overlay.ServiceAccountName = serviceAccountName
+	return j
+}
+
+// applyOverlay patches currentJob's target container (at index) and pod
+// template with overlay. It is called after DeepCopy and before Create.
+func applyOverlay(currentJob *v1.Job, index int, overlay JobOverlay) {
+	container := &currentJob.Spec.Template.Spec.Containers[index]
+	switch {
+	case overlay.Image != "":
+		container.Image = overlay.Image
+	case overlay.ImageTag != "":
+		container.Image = replaceImageTag(container.Image, overlay.ImageTag)
+	}
+	container.Env = mergeEnvVars(container.Env, overlay.Env)
+	container.EnvFrom = append(container.EnvFrom, overlay.EnvFrom...)
+	if !reflect.DeepEqual(overlay.Resources, corev1.ResourceRequirements{}) {
+		container.Resources = overlay.Resources
+	}
+
+	podSpec := &currentJob.Spec.Template.Spec
+	if overlay.NodeSelector != nil {
+		podSpec.NodeSelector = overlay.NodeSelector
+	}
+	if overlay.Tolerations != nil {
+		podSpec.Tolerations = overlay.Tolerations
+	}
+	if overlay.ServiceAccountName != "" {
+		podSpec.ServiceAccountName = overlay.ServiceAccountName
+	}
+
+	currentJob.Labels = mergeStringMaps(currentJob.Labels, overlay.Labels)
+	currentJob.Spec.Template.Labels = mergeStringMaps(currentJob.Spec.Template.Labels, overlay.Labels)
+	currentJob.Annotations = mergeStringMaps(currentJob.Annotations, overlay.Annotations)
+	currentJob.Spec.Template.Annotations = mergeStringMaps(currentJob.Spec.Template.Annotations, overlay.Annotations)
+
+	if overlay.BackoffLimit != nil {
+		currentJob.Spec.BackoffLimit = overlay.BackoffLimit
+	}
+	if overlay.ActiveDeadlineSeconds != nil {
+		currentJob.Spec.ActiveDeadlineSeconds = overlay.ActiveDeadlineSeconds
+	}
+}
+
+// replaceImageTag replaces the tag of image, leaving the registry/repository
+// untouched even when the registry host itself carries a port.
+func replaceImageTag(image, tag string) string {
+	idx := strings.LastIndex(image, ":")
+	if idx == -1 || strings.Contains(image[idx+1:], "/") {
+		return image + ":" + tag
+	}
+	return image[:idx] + ":" + tag
+}
+
+// mergeEnvVars merges overlay into base by name, overlay wins.
+func mergeEnvVars(base, overlay []corev1.EnvVar) []corev1.EnvVar {
+	if len(overlay) == 0 {
+		return base
+	}
+
+	merged := make([]corev1.EnvVar, len(base), len(base)+len(overlay))
+	copy(merged, base)
+	index := make(map[string]int, len(merged))
+	for i, env := range merged {
+		index[env.Name] = i
+	}
+	for _, env := range overlay {
+		if i, ok := index[env.Name]; ok {
+			merged[i] = env
+			continue
+		}
+		index[env.Name] = len(merged)
+		merged = append(merged, env)
+	}
+	return merged
+}
+
+// mergeStringMaps merges overlay into base, overlay wins.
+func mergeStringMaps(base, overlay map[string]string) map[string]string {
+	if len(overlay) == 0 {
+		return base
+	}
+
+	merged := make(map[string]string, len(base)+len(overlay))
+	for k, v := range base {
+		merged[k] = v
+	}
+	for k, v := range overlay {
+		merged[k] = v
+	}
+	return merged
+}
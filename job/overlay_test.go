@@ -0,0 +1,278 @@
+package job
+
+import (
+	"reflect"
+	"testing"
+
+	v1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+func newOverlayTestJob() *v1.Job {
+	return &v1.Job{
+		Spec: v1.JobSpec{
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{
+						{
+							Name:  "main",
+							Image: "example.com/app:v1",
+							Env: []corev1.EnvVar{
+								{Name: "FOO", Value: "base"},
+								{Name: "BAR", Value: "base"},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestApplyOverlayImage(t *testing.T) {
+	currentJob := newOverlayTestJob()
+	applyOverlay(currentJob, 0, JobOverlay{Image: "example.com/app:v2"})
+
+	if got := currentJob.Spec.Template.Spec.Containers[0].Image; got != "example.com/app:v2" {
+		t.Errorf("Image = %q, want %q", got, "example.com/app:v2")
+	}
+}
+
+func TestApplyOverlayImagePreferredOverImageTag(t *testing.T) {
+	currentJob := newOverlayTestJob()
+	applyOverlay(currentJob, 0, JobOverlay{Image: "example.com/app:v2", ImageTag: "v3"})
+
+	if got := currentJob.Spec.Template.Spec.Containers[0].Image; got != "example.com/app:v2" {
+		t.Errorf("Image should win over ImageTag: got %q, want %q", got, "example.com/app:v2")
+	}
+}
+
+func TestApplyOverlayImageTag(t *testing.T) {
+	currentJob := newOverlayTestJob()
+	applyOverlay(currentJob, 0, JobOverlay{ImageTag: "v2"})
+
+	if got := currentJob.Spec.Template.Spec.Containers[0].Image; got != "example.com/app:v2" {
+		t.Errorf("Image = %q, want %q", got, "example.com/app:v2")
+	}
+}
+
+func TestApplyOverlayEnv(t *testing.T) {
+	currentJob := newOverlayTestJob()
+	applyOverlay(currentJob, 0, JobOverlay{
+		Env: []corev1.EnvVar{
+			{Name: "BAR", Value: "overlay"},
+			{Name: "BAZ", Value: "overlay"},
+		},
+	})
+
+	want := []corev1.EnvVar{
+		{Name: "FOO", Value: "base"},
+		{Name: "BAR", Value: "overlay"},
+		{Name: "BAZ", Value: "overlay"},
+	}
+	if got := currentJob.Spec.Template.Spec.Containers[0].Env; !reflect.DeepEqual(got, want) {
+		t.Errorf("Env = %+v, want %+v", got, want)
+	}
+}
+
+func TestApplyOverlayEnvFrom(t *testing.T) {
+	currentJob := newOverlayTestJob()
+	envFrom := corev1.EnvFromSource{ConfigMapRef: &corev1.ConfigMapEnvSource{
+		LocalObjectReference: corev1.LocalObjectReference{Name: "cm"},
+	}}
+	applyOverlay(currentJob, 0, JobOverlay{EnvFrom: []corev1.EnvFromSource{envFrom}})
+
+	got := currentJob.Spec.Template.Spec.Containers[0].EnvFrom
+	if len(got) != 1 || !reflect.DeepEqual(got[0], envFrom) {
+		t.Errorf("EnvFrom = %+v, want [%+v]", got, envFrom)
+	}
+}
+
+func TestApplyOverlayResources(t *testing.T) {
+	currentJob := newOverlayTestJob()
+	resources := corev1.ResourceRequirements{
+		Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("100m")},
+	}
+	applyOverlay(currentJob, 0, JobOverlay{Resources: resources})
+
+	if got := currentJob.Spec.Template.Spec.Containers[0].Resources; !reflect.DeepEqual(got, resources) {
+		t.Errorf("Resources = %+v, want %+v", got, resources)
+	}
+}
+
+func TestApplyOverlayResourcesZeroValueIsNoop(t *testing.T) {
+	currentJob := newOverlayTestJob()
+	currentJob.Spec.Template.Spec.Containers[0].Resources = corev1.ResourceRequirements{
+		Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("200m")},
+	}
+	applyOverlay(currentJob, 0, JobOverlay{})
+
+	got := currentJob.Spec.Template.Spec.Containers[0].Resources.Requests.Cpu().String()
+	if got != "200m" {
+		t.Errorf("empty overlay should not touch Resources: got %q, want %q", got, "200m")
+	}
+}
+
+func TestApplyOverlayNodeSelector(t *testing.T) {
+	currentJob := newOverlayTestJob()
+	applyOverlay(currentJob, 0, JobOverlay{NodeSelector: map[string]string{"disk": "ssd"}})
+
+	want := map[string]string{"disk": "ssd"}
+	if got := currentJob.Spec.Template.Spec.NodeSelector; !reflect.DeepEqual(got, want) {
+		t.Errorf("NodeSelector = %+v, want %+v", got, want)
+	}
+}
+
+func TestApplyOverlayTolerations(t *testing.T) {
+	currentJob := newOverlayTestJob()
+	tolerations := []corev1.Toleration{{Key: "dedicated", Operator: corev1.TolerationOpExists}}
+	applyOverlay(currentJob, 0, JobOverlay{Tolerations: tolerations})
+
+	if got := currentJob.Spec.Template.Spec.Tolerations; !reflect.DeepEqual(got, tolerations) {
+		t.Errorf("Tolerations = %+v, want %+v", got, tolerations)
+	}
+}
+
+func TestApplyOverlayLabelsAndAnnotations(t *testing.T) {
+	currentJob := newOverlayTestJob()
+	currentJob.Labels = map[string]string{"app": "base"}
+	currentJob.Spec.Template.Labels = map[string]string{"app": "base"}
+
+	applyOverlay(currentJob, 0, JobOverlay{
+		Labels:      map[string]string{"env": "ci"},
+		Annotations: map[string]string{"team": "platform"},
+	})
+
+	wantLabels := map[string]string{"app": "base", "env": "ci"}
+	if got := currentJob.Labels; !reflect.DeepEqual(got, wantLabels) {
+		t.Errorf("Job Labels = %+v, want %+v", got, wantLabels)
+	}
+	if got := currentJob.Spec.Template.Labels; !reflect.DeepEqual(got, wantLabels) {
+		t.Errorf("PodTemplate Labels = %+v, want %+v", got, wantLabels)
+	}
+
+	wantAnnotations := map[string]string{"team": "platform"}
+	if got := currentJob.Annotations; !reflect.DeepEqual(got, wantAnnotations) {
+		t.Errorf("Job Annotations = %+v, want %+v", got, wantAnnotations)
+	}
+	if got := currentJob.Spec.Template.Annotations; !reflect.DeepEqual(got, wantAnnotations) {
+		t.Errorf("PodTemplate Annotations = %+v, want %+v", got, wantAnnotations)
+	}
+}
+
+func TestApplyOverlayBackoffLimitAndActiveDeadline(t *testing.T) {
+	currentJob := newOverlayTestJob()
+	backoffLimit := int32(5)
+	activeDeadline := int64(60)
+	applyOverlay(currentJob, 0, JobOverlay{
+		BackoffLimit:          &backoffLimit,
+		ActiveDeadlineSeconds: &activeDeadline,
+	})
+
+	if currentJob.Spec.BackoffLimit == nil || *currentJob.Spec.BackoffLimit != backoffLimit {
+		t.Errorf("BackoffLimit = %v, want %d", currentJob.Spec.BackoffLimit, backoffLimit)
+	}
+	if currentJob.Spec.ActiveDeadlineSeconds == nil || *currentJob.Spec.ActiveDeadlineSeconds != activeDeadline {
+		t.Errorf("ActiveDeadlineSeconds = %v, want %d", currentJob.Spec.ActiveDeadlineSeconds, activeDeadline)
+	}
+}
+
+func TestApplyOverlayServiceAccountName(t *testing.T) {
+	currentJob := newOverlayTestJob()
+	applyOverlay(currentJob, 0, JobOverlay{ServiceAccountName: "deployer"})
+
+	if got := currentJob.Spec.Template.Spec.ServiceAccountName; got != "deployer" {
+		t.Errorf("ServiceAccountName = %q, want %q", got, "deployer")
+	}
+}
+
+func TestReplaceImageTag(t *testing.T) {
+	tests := []struct {
+		name  string
+		image string
+		tag   string
+		want  string
+	}{
+		{"no existing tag", "example.com/app", "v2", "example.com/app:v2"},
+		{"existing tag replaced", "example.com/app:v1", "v2", "example.com/app:v2"},
+		{"registry host with port", "registry.internal:5000/app", "v2", "registry.internal:5000/app:v2"},
+		{"registry host with port and tag", "registry.internal:5000/app:v1", "v2", "registry.internal:5000/app:v2"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := replaceImageTag(tt.image, tt.tag); got != tt.want {
+				t.Errorf("replaceImageTag(%q, %q) = %q, want %q", tt.image, tt.tag, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMergeEnvVars(t *testing.T) {
+	base := []corev1.EnvVar{
+		{Name: "FOO", Value: "base"},
+		{Name: "BAR", Value: "base"},
+	}
+	overlay := []corev1.EnvVar{
+		{Name: "BAR", Value: "overlay"},
+		{Name: "BAZ", Value: "overlay"},
+	}
+
+	got := mergeEnvVars(base, overlay)
+	want := []corev1.EnvVar{
+		{Name: "FOO", Value: "base"},
+		{Name: "BAR", Value: "overlay"},
+		{Name: "BAZ", Value: "overlay"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("mergeEnvVars = %+v, want %+v", got, want)
+	}
+}
+
+func TestMergeEnvVarsEmptyOverlayReturnsBase(t *testing.T) {
+	base := []corev1.EnvVar{{Name: "FOO", Value: "base"}}
+	if got := mergeEnvVars(base, nil); !reflect.DeepEqual(got, base) {
+		t.Errorf("mergeEnvVars with empty overlay = %+v, want %+v", got, base)
+	}
+}
+
+func TestMergeStringMaps(t *testing.T) {
+	base := map[string]string{"app": "base", "env": "base"}
+	overlay := map[string]string{"env": "overlay", "team": "overlay"}
+
+	got := mergeStringMaps(base, overlay)
+	want := map[string]string{"app": "base", "env": "overlay", "team": "overlay"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("mergeStringMaps = %+v, want %+v", got, want)
+	}
+}
+
+func TestMergeStringMapsNilBaseAndOverlay(t *testing.T) {
+	if got := mergeStringMaps(nil, nil); got != nil {
+		t.Errorf("mergeStringMaps(nil, nil) = %+v, want nil", got)
+	}
+	if got := mergeStringMaps(nil, map[string]string{"a": "b"}); !reflect.DeepEqual(got, map[string]string{"a": "b"}) {
+		t.Errorf("mergeStringMaps(nil, overlay) = %+v, want %+v", got, map[string]string{"a": "b"})
+	}
+}
+
+func TestWithMethodsChainAndMutateOverlay(t *testing.T) {
+	j := &Job{}
+	j.WithImage("example.com/app:v2").
+		WithEnv(corev1.EnvVar{Name: "FOO", Value: "bar"}).
+		WithLabels(map[string]string{"env": "ci"}).
+		WithBackoffLimit(3)
+
+	if j.Overlay.Image != "example.com/app:v2" {
+		t.Errorf("Overlay.Image = %q, want %q", j.Overlay.Image, "example.com/app:v2")
+	}
+	if len(j.Overlay.Env) != 1 || j.Overlay.Env[0].Name != "FOO" {
+		t.Errorf("Overlay.Env = %+v, want one FOO entry", j.Overlay.Env)
+	}
+	if j.Overlay.Labels["env"] != "ci" {
+		t.Errorf("Overlay.Labels = %+v, want env=ci", j.Overlay.Labels)
+	}
+	if j.Overlay.BackoffLimit == nil || *j.Overlay.BackoffLimit != 3 {
+		t.Errorf("Overlay.BackoffLimit = %v, want 3", j.Overlay.BackoffLimit)
+	}
+}
@@ -0,0 +1,302 @@
+package job
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/pkg/errors"
+	"oras.land/oras-go/v2"
+	"oras.land/oras-go/v2/content"
+	"oras.land/oras-go/v2/registry/remote"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// TemplateSource fetches the raw bytes of a job template.
+type TemplateSource interface {
+	Fetch(ctx context.Context) (io.ReadCloser, error)
+}
+
+// TemplateSourceOption configures the source returned by NewTemplateSource.
+type TemplateSourceOption func(*templateSourceConfig)
+
+type templateSourceConfig struct {
+	transport http.RoundTripper
+	client    kubernetes.Interface
+}
+
+// WithHTTPTransport sets the http.RoundTripper used by the https:// source,
+// so callers can inject authentication (e.g. a GitHub token) instead of
+// NewTemplateSource reading it from the environment.
+func WithHTTPTransport(transport http.RoundTripper) TemplateSourceOption {
+	return func(cfg *templateSourceConfig) {
+		cfg.transport = transport
+	}
+}
+
+// WithKubernetesClient sets the client used by the configmap:// source.
+func WithKubernetesClient(client kubernetes.Interface) TemplateSourceOption {
+	return func(cfg *templateSourceConfig) {
+		cfg.client = client
+	}
+}
+
+// NewTemplateSource builds a TemplateSource from rawurl. It understands:
+//
+//	file:// or a bare path  - read from local disk (the previous behavior)
+//	https://                - plain HTTP GET, optionally authenticated via WithHTTPTransport
+//	s3://bucket/key         - fetched with aws-sdk-go-v2
+//	oci://registry/repo:tag - the first layer of an OCI artifact, fetched via ORAS
+//	configmap://ns/name/key - a key of a Kubernetes ConfigMap, via WithKubernetesClient
+//	git+https://host/repo.git//path@ref - a file inside a git repo checked out at ref
+func NewTemplateSource(rawurl string, opts ...TemplateSourceOption) (TemplateSource, error) {
+	cfg := &templateSourceConfig{}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	if strings.HasPrefix(rawurl, "git+") {
+		return newGitSource(rawurl)
+	}
+
+	u, err := url.Parse(rawurl)
+	if err != nil || u.Scheme == "" {
+		return &fileSource{path: rawurl}, nil
+	}
+
+	switch u.Scheme {
+	case "file":
+		return &fileSource{path: u.Path}, nil
+	case "https":
+		return &httpSource{url: rawurl, transport: cfg.transport}, nil
+	case "s3":
+		return &s3Source{bucket: u.Host, key: strings.TrimPrefix(u.Path, "/")}, nil
+	case "oci":
+		return &ociSource{ref: strings.TrimPrefix(rawurl, "oci://")}, nil
+	case "configmap":
+		if cfg.client == nil {
+			return nil, errors.New("configmap:// template source requires WithKubernetesClient")
+		}
+		parts := strings.SplitN(strings.TrimPrefix(u.Path, "/"), "/", 2)
+		if u.Host == "" || len(parts) != 2 {
+			return nil, fmt.Errorf("invalid configmap template source %q, want configmap://namespace/name/key", rawurl)
+		}
+		return &configMapSource{client: cfg.client, namespace: u.Host, name: parts[0], key: parts[1]}, nil
+	default:
+		return nil, fmt.Errorf("unsupported template source scheme %q", u.Scheme)
+	}
+}
+
+// fileSource reads a template from local disk.
+type fileSource struct {
+	path string
+}
+
+func (s *fileSource) Fetch(ctx context.Context) (io.ReadCloser, error) {
+	return os.Open(s.path)
+}
+
+// httpSource reads a template over plain HTTPS.
+type httpSource struct {
+	url       string
+	transport http.RoundTripper
+}
+
+func (s *httpSource) Fetch(ctx context.Context) (io.ReadCloser, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	transport := s.transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	resp, err := (&http.Client{Transport: transport}).Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("could not read template file from %s: %s", s.url, resp.Status)
+	}
+	return resp.Body, nil
+}
+
+// s3Source reads a template from an S3 object.
+type s3Source struct {
+	bucket string
+	key    string
+}
+
+func (s *s3Source) Fetch(ctx context.Context) (io.ReadCloser, error) {
+	awsCfg, err := awsconfig.LoadDefaultConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+	out, err := s3.NewFromConfig(awsCfg).GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(s.key),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out.Body, nil
+}
+
+// ociSource reads the first layer of an OCI artifact, such as a raw YAML
+// file or Helm-style chart pushed to a registry.
+type ociSource struct {
+	ref string
+}
+
+func (s *ociSource) Fetch(ctx context.Context) (io.ReadCloser, error) {
+	repo, err := remote.NewRepository(s.ref)
+	if err != nil {
+		return nil, err
+	}
+
+	manifestDesc, err := oras.Resolve(ctx, repo, s.ref, oras.DefaultResolveOptions)
+	if err != nil {
+		return nil, err
+	}
+	manifestBytes, err := content.FetchAll(ctx, repo, manifestDesc)
+	if err != nil {
+		return nil, err
+	}
+
+	var manifest ocispec.Manifest
+	if err := json.Unmarshal(manifestBytes, &manifest); err != nil {
+		return nil, err
+	}
+	if len(manifest.Layers) == 0 {
+		return nil, fmt.Errorf("oci artifact %s has no layers", s.ref)
+	}
+
+	layer, err := content.FetchAll(ctx, repo, manifest.Layers[0])
+	if err != nil {
+		return nil, err
+	}
+	return io.NopCloser(bytes.NewReader(layer)), nil
+}
+
+// configMapSource reads a template from a key of a Kubernetes ConfigMap.
+type configMapSource struct {
+	client    kubernetes.Interface
+	namespace string
+	name      string
+	key       string
+}
+
+func (s *configMapSource) Fetch(ctx context.Context) (io.ReadCloser, error) {
+	cm, err := s.client.CoreV1().ConfigMaps(s.namespace).Get(ctx, s.name, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	data, ok := cm.Data[s.key]
+	if !ok {
+		return nil, fmt.Errorf("key %q not found in configmap %s/%s", s.key, s.namespace, s.name)
+	}
+	return io.NopCloser(strings.NewReader(data)), nil
+}
+
+// gitSource reads a file out of a git repository checked out at a ref.
+type gitSource struct {
+	repoURL string
+	path    string
+	ref     string
+}
+
+// newGitSource parses a "git+https://host/repo.git//path@ref" url.
+func newGitSource(rawurl string) (*gitSource, error) {
+	trimmed := strings.TrimPrefix(rawurl, "git+")
+	idx := strings.Index(trimmed, ".git//")
+	if idx == -1 {
+		return nil, fmt.Errorf("invalid git template source %q, want git+https://host/repo.git//path@ref", rawurl)
+	}
+	rest := trimmed[idx+len(".git//"):]
+	at := strings.LastIndex(rest, "@")
+	if at == -1 {
+		return nil, fmt.Errorf("invalid git template source %q, missing @ref", rawurl)
+	}
+
+	return &gitSource{
+		repoURL: trimmed[:idx+len(".git")],
+		path:    rest[:at],
+		ref:     rest[at+1:],
+	}, nil
+}
+
+func (s *gitSource) Fetch(ctx context.Context) (io.ReadCloser, error) {
+	dir, err := os.MkdirTemp("", "kube-job-git-*")
+	if err != nil {
+		return nil, err
+	}
+
+	// ref may be a branch, a tag, or a commit SHA, so clone fully and
+	// resolve+checkout it explicitly instead of passing it straight to
+	// CloneOptions.ReferenceName, which only accepts fully-qualified refs.
+	repo, err := git.PlainCloneContext(ctx, dir, false, &git.CloneOptions{
+		URL: s.repoURL,
+	})
+	if err != nil {
+		os.RemoveAll(dir)
+		return nil, err
+	}
+
+	hash, err := repo.ResolveRevision(plumbing.Revision(s.ref))
+	if err != nil {
+		// A clone only creates a local branch for the remote HEAD; every
+		// other branch exists only as origin/<branch>.
+		hash, err = repo.ResolveRevision(plumbing.Revision("origin/" + s.ref))
+	}
+	if err != nil {
+		os.RemoveAll(dir)
+		return nil, fmt.Errorf("could not resolve git ref %q: %w", s.ref, err)
+	}
+
+	worktree, err := repo.Worktree()
+	if err != nil {
+		os.RemoveAll(dir)
+		return nil, err
+	}
+	if err := worktree.Checkout(&git.CheckoutOptions{Hash: *hash}); err != nil {
+		os.RemoveAll(dir)
+		return nil, err
+	}
+
+	f, err := os.Open(filepath.Join(dir, s.path))
+	if err != nil {
+		os.RemoveAll(dir)
+		return nil, err
+	}
+	return &cleanupReadCloser{ReadCloser: f, dir: dir}, nil
+}
+
+// cleanupReadCloser removes a temporary directory once the wrapped file is
+// closed, so git checkouts don't accumulate under the OS temp dir.
+type cleanupReadCloser struct {
+	io.ReadCloser
+	dir string
+}
+
+func (c *cleanupReadCloser) Close() error {
+	err := c.ReadCloser.Close()
+	os.RemoveAll(c.dir)
+	return err
+}